@@ -1,12 +1,18 @@
 package main
 
 import (
-	"github.com/libp2p/go-libp2p/core/network"
+	"encoding/json"
+	"fmt"
 	"log"
+	"os"
+
+	"github.com/libp2p/go-libp2p/core/network"
 
 	"github.com/dustin/go-humanize"
 	"github.com/ipfs/rainbow/internal/fd"
 	"github.com/libp2p/go-libp2p"
+	"github.com/multiformats/go-multiaddr"
+
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	"github.com/pbnjay/memory"
 )
@@ -16,45 +22,239 @@ import (
 
 var infiniteResourceLimits = rcmgr.InfiniteLimits.ToPartialLimitConfig().System
 
-func makeResourceMgrs(maxMemory uint64, maxFD int, connMgrHighWater int, separateDHT bool) (bitswapHost, dhtHost network.ResourceManager, err error) {
+// rcmgrLimitsFile, when non-empty (--rcmgr-limits-file), points at a JSON
+// encoded rcmgr.PartialLimitConfig that is layered on top of the computed
+// defaults: any scope/field the user sets wins, anything left zero-valued
+// falls through to what we would have computed anyway. This mirrors kubo's
+// Swarm.ResourceMgr.Limits behavior. It's reported back by
+// /debug/rcmgr/limits so operators can tell which file a given set of
+// effective limits came from.
+var rcmgrLimitsFile string
+
+// currentRcmgrLimiters holds the reloadable limiters backing the live
+// resource managers so that SIGHUP and /debug/rcmgr/limits can inspect and
+// swap the effective limits without restarting the daemon.
+var currentRcmgrLimiters = struct {
+	bitswap *reloadableLimiter
+	dht     *reloadableLimiter
+}{}
+
+// currentRcmgrManagers holds the live resource managers themselves (as
+// opposed to just the limiters backing them), so the admin HTTP surface can
+// walk their per-scope stats via rcmgr.ResourceManagerState.
+var currentRcmgrManagers = struct {
+	bitswap network.ResourceManager
+	dht     network.ResourceManager
+}{}
+
+// currentRcmgrAllowlist is the set of multiaddrs (--rcmgr-allowlist) that
+// bypass the System/Transient conn and FD caps, exposed read-only via the
+// admin endpoint.
+var currentRcmgrAllowlist []multiaddr.Multiaddr
+
+// Defaults for the knobs ResourceManagerConfig exposes. A MaxMemoryFraction
+// of 0 means "don't derive from a flat fraction of host RAM, use the
+// autoscaled default instead" (see computeDefaultMaxMemory); a MaxFDFraction
+// of 0 means defaultMaxFDFraction (see computeDefaultMaxFD).
+const (
+	defaultMaxFDFraction      = 0.5
+	defaultBitswapMemoryShare = 0.85
+	defaultBitswapFDShare     = 0.75
+)
+
+// ResourceManagerConfig bundles the tunables that shape the bitswap and DHT
+// resource managers. It exists because the underlying knobs (explicit caps,
+// fractions of host resources, and the bitswap/DHT split) outgrew a plain
+// parameter list as operators asked for more control over each of them.
+type ResourceManagerConfig struct {
+	// MaxMemory, if non-zero, is used as-is. Otherwise it's derived from
+	// MaxMemoryFraction (a flat fraction of host RAM) if set, or from
+	// go-libp2p's autoscaled default sizing curve otherwise.
+	MaxMemory         uint64
+	MaxMemoryFraction float64
+
+	// MaxFD, if non-zero, is used as-is. Otherwise it's derived from
+	// MaxFDFraction (defaulting to 0.5) of the process's FD ulimit.
+	MaxFD         int
+	MaxFDFraction float64
+
+	// BitswapMemoryShare and DHTMemoryShare split MaxMemory between the two
+	// hosts when SeparateDHT is set. DHTMemoryShare defaults to whatever
+	// BitswapMemoryShare leaves over; if DHTMemoryShare is set, its sum with
+	// BitswapMemoryShare (explicit or defaulted) must not exceed 1 so the two
+	// resource managers don't double-book host memory.
+	BitswapMemoryShare float64
+	DHTMemoryShare     float64
+
+	ConnMgrHighWater int
+	SeparateDHT      bool
+	LimitsFile       string
+	Allowlist        []string
+}
+
+// computeDefaultMaxMemory mirrors what kubo does when Swarm.ResourceMgr.MaxMemory
+// is left unset: rather than a blunt fraction of host RAM (which is too
+// aggressive on small VMs), fall back to go-libp2p's AutoScale() sizing
+// curve, which scales proportionally to host memory with a floor and
+// ceiling. A non-zero fraction always takes precedence when the operator
+// wants the old flat-percentage behavior.
+func computeDefaultMaxMemory(maxMemoryFraction float64) uint64 {
+	if maxMemoryFraction > 0 {
+		return uint64(float64(memory.TotalMemory()) * maxMemoryFraction)
+	}
+	return uint64(rcmgr.DefaultLimits.AutoScale().ToPartialLimitConfig().System.Memory)
+}
+
+// computeDefaultMaxFD derives the default max FD count from a fraction of
+// the number of FDs available to the process, defaulting to half of them.
+func computeDefaultMaxFD(maxFDFraction float64) int {
+	if maxFDFraction == 0 {
+		maxFDFraction = defaultMaxFDFraction
+	}
+	return int(float64(fd.GetNumFDs()) * maxFDFraction)
+}
+
+func makeResourceMgrs(cfg ResourceManagerConfig) (bitswapHost, dhtHost network.ResourceManager, err error) {
+	maxMemory := cfg.MaxMemory
 	if maxMemory == 0 {
-		maxMemory = uint64((float64(memory.TotalMemory()) * 0.85))
+		maxMemory = computeDefaultMaxMemory(cfg.MaxMemoryFraction)
 	}
+	maxFD := cfg.MaxFD
 	if maxFD == 0 {
-		maxFD = fd.GetNumFDs() / 2
+		maxFD = computeDefaultMaxFD(cfg.MaxFDFraction)
+	}
+
+	rcmgrLimitsFile = cfg.LimitsFile
+	userLimits, err := loadUserLimitsFile(cfg.LimitsFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowlistedAddrs, err := parseAllowlist(cfg.Allowlist)
+	if err != nil {
+		return nil, nil, err
+	}
+	currentRcmgrAllowlist = allowlistedAddrs
+
+	reporters, err := newRcmgrReporters()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if !separateDHT {
-		mgr, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(makeResourceManagerConfig(maxMemory, maxFD, connMgrHighWater)))
+	if !cfg.SeparateDHT {
+		base := makeResourceManagerConfig(maxMemory, maxFD, cfg.ConnMgrHighWater)
+		limiter := newReloadableLimiter(base)
+		limiter.store(applyUserLimits(userLimits, base))
+		currentRcmgrLimiters.bitswap = limiter
+		mgr, err := rcmgr.NewResourceManager(limiter, rcmgr.WithAllowlistedMultiaddrs(allowlistedAddrs), reporters)
 		if err != nil {
 			return nil, nil, err
 		}
+		currentRcmgrManagers.bitswap = mgr
+		watchLimitsFileForReload(cfg.LimitsFile)
 		return mgr, nil, nil
 	}
 
-	bitswapHostMem := uint64(float64(maxMemory) * 0.85)
-	bitswapHostFDs := int(float64(maxFD) * 0.75)
-	bitswapHostRcMgr, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(makeResourceManagerConfig(bitswapHostMem, bitswapHostFDs, connMgrHighWater)))
+	bitswapMemoryShare := cfg.BitswapMemoryShare
+	if bitswapMemoryShare == 0 {
+		bitswapMemoryShare = defaultBitswapMemoryShare
+	}
+	dhtMemoryShare := cfg.DHTMemoryShare
+	if dhtMemoryShare > 0 && bitswapMemoryShare+dhtMemoryShare > 1 {
+		return nil, nil, fmt.Errorf("--bitswap-memory-share (%v) + --dht-memory-share (%v) must not exceed 1, otherwise the two resource managers double-book host memory", bitswapMemoryShare, dhtMemoryShare)
+	}
+	bitswapFDShare := defaultBitswapFDShare
+
+	bitswapHostMem := uint64(float64(maxMemory) * bitswapMemoryShare)
+	bitswapHostFDs := int(float64(maxFD) * bitswapFDShare)
+	bitswapBase := makeResourceManagerConfig(bitswapHostMem, bitswapHostFDs, cfg.ConnMgrHighWater)
+	bitswapLimiter := newReloadableLimiter(bitswapBase)
+	bitswapLimiter.store(applyUserLimits(userLimits, bitswapBase))
+	currentRcmgrLimiters.bitswap = bitswapLimiter
+	bitswapHostRcMgr, err := rcmgr.NewResourceManager(bitswapLimiter, rcmgr.WithAllowlistedMultiaddrs(allowlistedAddrs), reporters)
 	if err != nil {
 		return nil, nil, err
 	}
+	currentRcmgrManagers.bitswap = bitswapHostRcMgr
 
-	dhtHostMem := maxMemory - bitswapHostMem
+	var dhtHostMem uint64
+	if dhtMemoryShare > 0 {
+		dhtHostMem = uint64(float64(maxMemory) * dhtMemoryShare)
+	} else {
+		dhtHostMem = maxMemory - bitswapHostMem
+	}
 	dhtHostFDs := maxFD - bitswapHostFDs
-	dhtHostRcMgr, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(makeSeparateDHTClientResourceManagerConfig(dhtHostMem, dhtHostFDs)))
+	dhtBase := makeSeparateDHTClientResourceManagerConfig(dhtHostMem, dhtHostFDs)
+	dhtLimiter := newReloadableLimiter(dhtBase)
+	dhtLimiter.store(applyUserLimits(userLimits, dhtBase))
+	currentRcmgrLimiters.dht = dhtLimiter
+	dhtHostRcMgr, err := rcmgr.NewResourceManager(dhtLimiter, rcmgr.WithAllowlistedMultiaddrs(allowlistedAddrs), reporters)
 	if err != nil {
 		return nil, nil, err
 	}
+	currentRcmgrManagers.dht = dhtHostRcMgr
+
+	watchLimitsFileForReload(cfg.LimitsFile)
 
 	return bitswapHostRcMgr, dhtHostRcMgr, nil
 }
 
+// parseAllowlist validates and parses --rcmgr-allowlist entries (plain
+// multiaddrs, ipcidr multiaddrs such as /ip4/1.2.3.4/ipcidr/24, and
+// peer-id-suffixed multiaddrs) into multiaddr.Multiaddr values suitable for
+// rcmgr.WithAllowlistedMultiaddrs.
+func parseAllowlist(allowlist []string) ([]multiaddr.Multiaddr, error) {
+	if len(allowlist) == 0 {
+		return nil, nil
+	}
+
+	addrs := make([]multiaddr.Multiaddr, 0, len(allowlist))
+	for _, s := range allowlist {
+		addr, err := multiaddr.NewMultiaddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --rcmgr-allowlist entry %q: %w", s, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// loadUserLimitsFile reads and decodes a JSON rcmgr.PartialLimitConfig from
+// path. An empty path is not an error: it simply means there are no user
+// overrides to apply.
+func loadUserLimitsFile(path string) (rcmgr.PartialLimitConfig, error) {
+	if path == "" {
+		return rcmgr.PartialLimitConfig{}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return rcmgr.PartialLimitConfig{}, fmt.Errorf("opening rcmgr limits file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	var userLimits rcmgr.PartialLimitConfig
+	if err := json.NewDecoder(f).Decode(&userLimits); err != nil {
+		return rcmgr.PartialLimitConfig{}, fmt.Errorf("decoding rcmgr limits file %q: %w", path, err)
+	}
+
+	log.Printf("loaded rcmgr limits overrides from %q", path)
+	return userLimits, nil
+}
+
+// applyUserLimits layers userLimits on top of computed, such that any
+// non-default value set by the user wins and everything else falls back to
+// what was already computed.
+func applyUserLimits(userLimits rcmgr.PartialLimitConfig, computed rcmgr.ConcreteLimitConfig) rcmgr.ConcreteLimitConfig {
+	return userLimits.Build(computed)
+}
+
 func makeResourceManagerConfig(maxMemory uint64, maxFD int, connMgrHighWater int) (limitConfig rcmgr.ConcreteLimitConfig) {
 	if maxMemory == 0 {
-		maxMemory = uint64((float64(memory.TotalMemory()) * 0.85))
+		maxMemory = computeDefaultMaxMemory(0)
 	}
 	if maxFD == 0 {
-		maxFD = fd.GetNumFDs() / 2
+		maxFD = computeDefaultMaxFD(0)
 	}
 
 	maxMemoryMB := maxMemory / (1024 * 1024)
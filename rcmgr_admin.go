@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// reloadableLimiter wraps a rcmgr.ConcreteLimitConfig behind an atomic
+// pointer so the effective limits backing a live network.ResourceManager can
+// be swapped out at runtime (e.g. on SIGHUP), without tearing down and
+// rebuilding the libp2p host it was constructed with.
+//
+// base is the limit config computed from host sizing (before any user
+// overrides from the limits file) and never changes after construction.
+// SIGHUP reloads must rebuild from base, not from the live snapshot: that's
+// what lets a change or removal in the limits file take effect, instead of
+// the stale override from a previous reload sticking around forever.
+type reloadableLimiter struct {
+	base    rcmgr.ConcreteLimitConfig
+	current atomic.Pointer[rcmgr.ConcreteLimitConfig]
+}
+
+func newReloadableLimiter(base rcmgr.ConcreteLimitConfig) *reloadableLimiter {
+	l := &reloadableLimiter{base: base}
+	l.store(base)
+	return l
+}
+
+func (l *reloadableLimiter) store(limits rcmgr.ConcreteLimitConfig) {
+	l.current.Store(&limits)
+}
+
+func (l *reloadableLimiter) snapshot() rcmgr.ConcreteLimitConfig {
+	return *l.current.Load()
+}
+
+func (l *reloadableLimiter) GetSystemLimits() rcmgr.Limit { return l.current.Load().GetSystemLimits() }
+func (l *reloadableLimiter) GetTransientLimits() rcmgr.Limit {
+	return l.current.Load().GetTransientLimits()
+}
+func (l *reloadableLimiter) GetAllowlistedSystemLimits() rcmgr.Limit {
+	return l.current.Load().GetAllowlistedSystemLimits()
+}
+func (l *reloadableLimiter) GetAllowlistedTransientLimits() rcmgr.Limit {
+	return l.current.Load().GetAllowlistedTransientLimits()
+}
+func (l *reloadableLimiter) GetServiceLimits(svc string) rcmgr.Limit {
+	return l.current.Load().GetServiceLimits(svc)
+}
+func (l *reloadableLimiter) GetServicePeerLimits(svc string) rcmgr.Limit {
+	return l.current.Load().GetServicePeerLimits(svc)
+}
+func (l *reloadableLimiter) GetProtocolLimits(proto protocol.ID) rcmgr.Limit {
+	return l.current.Load().GetProtocolLimits(proto)
+}
+func (l *reloadableLimiter) GetProtocolPeerLimits(proto protocol.ID) rcmgr.Limit {
+	return l.current.Load().GetProtocolPeerLimits(proto)
+}
+func (l *reloadableLimiter) GetPeerLimits(p peer.ID) rcmgr.Limit {
+	return l.current.Load().GetPeerLimits(p)
+}
+func (l *reloadableLimiter) GetStreamLimits(p peer.ID) rcmgr.Limit {
+	return l.current.Load().GetStreamLimits(p)
+}
+func (l *reloadableLimiter) GetConnLimits() rcmgr.Limit { return l.current.Load().GetConnLimits() }
+
+// watchLimitsFileForReload registers a SIGHUP handler that re-reads path,
+// re-layers it on top of each limiter's base (the defaults computed at
+// startup), and atomically swaps the
+// limits backing the live resource managers. A no-op when path is empty.
+func watchLimitsFileForReload(path string) {
+	if path == "" {
+		return
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		for range sighup {
+			userLimits, err := loadUserLimitsFile(path)
+			if err != nil {
+				log.Printf("rcmgr: failed to reload limits file %q on SIGHUP: %s", path, err)
+				continue
+			}
+
+			if l := currentRcmgrLimiters.bitswap; l != nil {
+				l.store(applyUserLimits(userLimits, l.base))
+			}
+			if l := currentRcmgrLimiters.dht; l != nil {
+				l.store(applyUserLimits(userLimits, l.base))
+			}
+			log.Printf("rcmgr: reloaded limits overrides from %q", path)
+		}
+	}()
+}
+
+// RegisterRcmgrDebugHandlers wires the rcmgr admin endpoints (stats, limits
+// dump, limits edit, allowlist) onto mux, gated behind the given bearer
+// token. This is NOT auto-registered: the PUT endpoint can set the system
+// scope's conns/FDs/memory to unlimited, i.e. fully disable the resource
+// manager's DoS protections, so callers must explicitly opt in by calling
+// this from wherever they already set up an operator-only admin surface
+// (e.g. a loopback-only listener), with a token sourced from config/secrets
+// rather than committed anywhere.
+func RegisterRcmgrDebugHandlers(mux *http.ServeMux, token string) error {
+	if token == "" {
+		return errors.New("rcmgr admin token must not be empty")
+	}
+
+	auth := requireRcmgrAdminToken(token)
+	mux.HandleFunc("/debug/rcmgr/limits", auth(handleDebugRcmgrLimits))
+	mux.HandleFunc("/debug/rcmgr/limits/", auth(handleDebugRcmgrLimitsScope))
+	mux.HandleFunc("/debug/rcmgr/allowlist", auth(handleDebugRcmgrAllowlist))
+	mux.HandleFunc("/debug/rcmgr/stats", auth(handleDebugRcmgrStats))
+	return nil
+}
+
+// requireRcmgrAdminToken wraps a handler so it only runs when the request
+// carries "Authorization: Bearer <token>" matching token, compared in
+// constant time to avoid leaking it through a timing side channel.
+func requireRcmgrAdminToken(token string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			const prefix = "Bearer "
+			got := r.Header.Get("Authorization")
+			if !strings.HasPrefix(got, prefix) ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(got, prefix)), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+func handleDebugRcmgrAllowlist(w http.ResponseWriter, r *http.Request) {
+	addrs := make([]string, 0, len(currentRcmgrAllowlist))
+	for _, addr := range currentRcmgrAllowlist {
+		addrs = append(addrs, addr.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(addrs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func handleDebugRcmgrLimits(w http.ResponseWriter, r *http.Request) {
+	limits := make(map[string]rcmgr.PartialLimitConfig, 2)
+	if l := currentRcmgrLimiters.bitswap; l != nil {
+		limits["bitswap"] = l.snapshot().ToPartialLimitConfig()
+	}
+	if l := currentRcmgrLimiters.dht; l != nil {
+		limits["dht"] = l.snapshot().ToPartialLimitConfig()
+	}
+
+	resp := struct {
+		LimitsFile string                              `json:"limitsFile,omitempty"`
+		Limits     map[string]rcmgr.PartialLimitConfig `json:"limits"`
+	}{
+		LimitsFile: rcmgrLimitsFile,
+		Limits:     limits,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
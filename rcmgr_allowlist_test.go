@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestParseAllowlist(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		addrs, err := parseAllowlist(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if addrs != nil {
+			t.Errorf("expected nil addrs, got %v", addrs)
+		}
+	})
+
+	t.Run("valid entries", func(t *testing.T) {
+		addrs, err := parseAllowlist([]string{
+			"/ip4/1.2.3.4/ipcidr/24",
+			"/ip4/5.6.7.8/tcp/4001/p2p/QmcgpsyWgH8Y8ajJz1Cu72KCC7bTiBJx1zz5dwCJkmnzvT",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(addrs) != 2 {
+			t.Fatalf("expected 2 parsed multiaddrs, got %d", len(addrs))
+		}
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		if _, err := parseAllowlist([]string{"not-a-multiaddr"}); err == nil {
+			t.Error("expected an error for an invalid multiaddr, got nil")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+	rcmgrObs "github.com/libp2p/go-libp2p/p2p/host/resource-manager/obs"
+)
+
+// rcmgrBreachLogInterval is how often the aggregated "resource limit
+// exceeded" counts are flushed to the log. Matches the cadence kubo settled
+// on: frequent enough to be useful while debugging a gateway under load,
+// infrequent enough not to flood the log with one line per rejected stream.
+const rcmgrBreachLogInterval = 10 * time.Second
+
+// newRcmgrReporters wires up the Prometheus stats reporter
+// (rcmgrObs.NewStatsTraceReporter) alongside a rate-limited logger that
+// aggregates "resource limit exceeded" errors, so a rcmgr.NewResourceManager
+// call site can pass both as a single rcmgr.WithTraceReporter option.
+func newRcmgrReporters() (rcmgr.Option, error) {
+	statsReporter, err := rcmgrObs.NewStatsTraceReporter()
+	if err != nil {
+		return nil, err
+	}
+
+	breachLogger := newRcmgrBreachLogger()
+
+	return rcmgr.WithTraceReporter(multiTraceReporter{statsReporter, breachLogger}), nil
+}
+
+// multiTraceReporter fans a single rcmgr trace event out to every configured
+// reporter.
+type multiTraceReporter []rcmgr.TraceReporter
+
+func (m multiTraceReporter) ConsumeEvent(evt rcmgr.TraceEvt) {
+	for _, r := range m {
+		r.ConsumeEvent(evt)
+	}
+}
+
+// rcmgrBreachLogger aggregates "resource limit exceeded" trace events into a
+// rolling window, logging one ERROR line per scope and limit kind every
+// rcmgrBreachLogInterval (with the number of rejections in that window,
+// instead of one line per rejected connection/stream), and a recovery line
+// once a window passes with no breaches.
+type rcmgrBreachLogger struct {
+	mu       sync.Mutex
+	counts   map[string]int // "scope: limit kind" -> occurrences this window
+	breached bool
+}
+
+func newRcmgrBreachLogger() *rcmgrBreachLogger {
+	l := &rcmgrBreachLogger{counts: make(map[string]int)}
+	go l.run()
+	return l
+}
+
+func (l *rcmgrBreachLogger) ConsumeEvent(evt rcmgr.TraceEvt) {
+	if !isLimitExceededEvent(evt) {
+		return
+	}
+
+	key := fmt.Sprintf("%s: %s", evt.Name, evt.Type)
+
+	l.mu.Lock()
+	l.counts[key]++
+	l.mu.Unlock()
+}
+
+func (l *rcmgrBreachLogger) run() {
+	ticker := time.NewTicker(rcmgrBreachLogInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		counts := l.counts
+		l.counts = make(map[string]int)
+		l.mu.Unlock()
+
+		if len(counts) == 0 {
+			if l.breached {
+				log.Printf("rcmgr: no resource limit breaches in the last %s, recovered", rcmgrBreachLogInterval)
+				l.breached = false
+			}
+			continue
+		}
+
+		l.breached = true
+		for key, n := range counts {
+			log.Printf("ERROR rcmgr: resource limit exceeded: %s (%d times in the last %s)", key, n, rcmgrBreachLogInterval)
+		}
+	}
+}
+
+// isLimitExceededEvent reports whether evt represents a rejected
+// reservation (as opposed to e.g. a successful Add/Remove accounting event).
+func isLimitExceededEvent(evt rcmgr.TraceEvt) bool {
+	switch evt.Type {
+	case rcmgr.TraceBlockAddConnEvt, rcmgr.TraceBlockAddStreamEvt, rcmgr.TraceBlockReserveMemoryEvt:
+		return true
+	default:
+		return false
+	}
+}
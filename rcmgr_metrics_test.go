@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+func TestIsLimitExceededEvent(t *testing.T) {
+	cases := []struct {
+		name string
+		typ  rcmgr.TraceEvtTyp
+		want bool
+	}{
+		{"blocked conn", rcmgr.TraceBlockAddConnEvt, true},
+		{"blocked stream", rcmgr.TraceBlockAddStreamEvt, true},
+		{"blocked memory reservation", rcmgr.TraceBlockReserveMemoryEvt, true},
+		{"successful conn accounting", rcmgr.TraceAddConnEvt, false},
+		{"successful stream accounting", rcmgr.TraceAddStreamEvt, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := isLimitExceededEvent(rcmgr.TraceEvt{Type: c.typ})
+			if got != c.want {
+				t.Errorf("isLimitExceededEvent(%v) = %v, want %v", c.typ, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pbnjay/memory"
+)
+
+func TestComputeDefaultMaxMemory(t *testing.T) {
+	t.Run("explicit fraction", func(t *testing.T) {
+		want := uint64(float64(memory.TotalMemory()) * 0.5)
+		if got := computeDefaultMaxMemory(0.5); got != want {
+			t.Errorf("computeDefaultMaxMemory(0.5) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("no fraction falls back to autoscale", func(t *testing.T) {
+		got := computeDefaultMaxMemory(0)
+		if got == 0 {
+			t.Error("expected a non-zero autoscaled default")
+		}
+		// The autoscale curve is bounded by the host's total memory.
+		if got > memory.TotalMemory() {
+			t.Errorf("autoscaled default %d exceeds host memory %d", got, memory.TotalMemory())
+		}
+	})
+}
+
+func TestComputeDefaultMaxFD(t *testing.T) {
+	t.Run("explicit fraction", func(t *testing.T) {
+		if got := computeDefaultMaxFD(1); got <= 0 {
+			t.Errorf("computeDefaultMaxFD(1) = %d, want > 0", got)
+		}
+	})
+
+	t.Run("zero fraction defaults to half", func(t *testing.T) {
+		got := computeDefaultMaxFD(0)
+		want := computeDefaultMaxFD(defaultMaxFDFraction)
+		if got != want {
+			t.Errorf("computeDefaultMaxFD(0) = %d, want %d (default fraction %v)", got, want, defaultMaxFDFraction)
+		}
+	})
+}
+
+func TestMakeResourceMgrsRejectsOverbookedMemoryShares(t *testing.T) {
+	_, _, err := makeResourceMgrs(ResourceManagerConfig{
+		MaxMemory:          1024 * 1024 * 1024,
+		MaxFD:              1000,
+		SeparateDHT:        true,
+		BitswapMemoryShare: 0.85, // same as the default
+		DHTMemoryShare:     0.5,  // 0.85 + 0.5 > 1: would double-book host memory
+	})
+	if err == nil {
+		t.Fatal("expected an error when bitswap and DHT memory shares sum to more than 1, got nil")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+// rcmgrScopeStat is the JSON shape returned by /debug/rcmgr/stats for a
+// single scope: the live usage (Stat) next to the limit it's measured
+// against, so operators can read utilization directly off one object. The
+// response as a whole is a map keyed by scope name (e.g. "bitswap:system",
+// "dht:peer:Qm...") rather than an array, matching the
+// scope-name-to-stat/limit shape of `ipfs swarm stats all` / `ipfs swarm
+// limit all`.
+type rcmgrScopeStat struct {
+	Stat  network.ScopeStat `json:"stat"`
+	Limit network.Limit     `json:"limit"`
+}
+
+func (s rcmgrScopeStat) usedLimitPerc() float64 {
+	max := 0.0
+	if s.Limit.GetMemoryLimit() > 0 {
+		max = maxFloat(max, float64(s.Stat.Memory)/float64(s.Limit.GetMemoryLimit()))
+	}
+	if fd := s.Limit.GetFDLimit(); fd > 0 {
+		max = maxFloat(max, float64(s.Stat.NumFD)/float64(fd))
+	}
+	if c := s.Limit.GetConnLimit(network.DirInbound); c > 0 {
+		max = maxFloat(max, float64(s.Stat.NumConnsInbound)/float64(c))
+	}
+	if c := s.Limit.GetConnLimit(network.DirOutbound); c > 0 {
+		max = maxFloat(max, float64(s.Stat.NumConnsOutbound)/float64(c))
+	}
+	if st := s.Limit.GetStreamLimit(network.DirInbound); st > 0 {
+		max = maxFloat(max, float64(s.Stat.NumStreamsInbound)/float64(st))
+	}
+	if st := s.Limit.GetStreamLimit(network.DirOutbound); st > 0 {
+		max = maxFloat(max, float64(s.Stat.NumStreamsOutbound)/float64(st))
+	}
+	return max * 100
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// resourceManagerState is the subset of rcmgr's introspection API this file
+// relies on (rcmgr.ResourceManagerState, implemented by
+// rcmgr.NewResourceManager's concrete return value), kept as a local
+// interface so collectRcmgrStats only needs a network.ResourceManager that
+// happens to also expose it. ViewService/ViewProtocol/ViewPeer each look up
+// a single named scope rather than enumerating, so List* is used first to
+// discover which names currently have a live scope.
+type resourceManagerState interface {
+	ViewSystem(func(network.ResourceScope) error) error
+	ViewTransient(func(network.ResourceScope) error) error
+	ViewService(string, func(network.ServiceScope) error) error
+	ViewProtocol(protocol.ID, func(network.ProtocolScope) error) error
+	ViewPeer(peer.ID, func(network.PeerScope) error) error
+	ListServices() []string
+	ListProtocols() []protocol.ID
+	ListPeers() []peer.ID
+}
+
+// collectRcmgrStats walks every scope of rm (system, transient, each
+// service, protocol, and peer scope) and returns a map of scope name
+// (prefixed with host, e.g. "bitswap" or "dht", so stats from both resource
+// managers can be reported side by side) to its stat/limit pair.
+func collectRcmgrStats(host string, rm network.ResourceManager) (map[string]rcmgrScopeStat, error) {
+	state, ok := rm.(resourceManagerState)
+	if !ok {
+		return nil, fmt.Errorf("resource manager for %q does not expose stats introspection", host)
+	}
+
+	stats := make(map[string]rcmgrScopeStat)
+
+	if err := state.ViewSystem(func(s network.ResourceScope) error {
+		stats[host+":system"] = rcmgrScopeStat{Stat: s.Stat(), Limit: s.Limit()}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := state.ViewTransient(func(s network.ResourceScope) error {
+		stats[host+":transient"] = rcmgrScopeStat{Stat: s.Stat(), Limit: s.Limit()}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, name := range state.ListServices() {
+		if err := state.ViewService(name, func(s network.ServiceScope) error {
+			stats[host+":service:"+name] = rcmgrScopeStat{Stat: s.Stat(), Limit: s.Limit()}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range state.ListProtocols() {
+		if err := state.ViewProtocol(p, func(s network.ProtocolScope) error {
+			stats[host+":protocol:"+string(p)] = rcmgrScopeStat{Stat: s.Stat(), Limit: s.Limit()}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range state.ListPeers() {
+		if err := state.ViewPeer(id, func(s network.PeerScope) error {
+			stats[host+":peer:"+id.String()] = rcmgrScopeStat{Stat: s.Stat(), Limit: s.Limit()}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// handleDebugRcmgrStats serves GET /debug/rcmgr/stats. The optional
+// min-used-limit-perc query parameter filters out scopes below that
+// utilization threshold, which matters once a node has accumulated
+// thousands of per-peer scopes.
+func handleDebugRcmgrStats(w http.ResponseWriter, r *http.Request) {
+	var minUsedLimitPerc float64
+	if v := r.URL.Query().Get("min-used-limit-perc"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid min-used-limit-perc: %s", err), http.StatusBadRequest)
+			return
+		}
+		minUsedLimitPerc = parsed
+	}
+
+	all := make(map[string]rcmgrScopeStat)
+	for host, rm := range map[string]network.ResourceManager{"bitswap": currentRcmgrManagers.bitswap, "dht": currentRcmgrManagers.dht} {
+		if rm == nil {
+			continue
+		}
+		stats, err := collectRcmgrStats(host, rm)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for scope, s := range stats {
+			all[scope] = s
+		}
+	}
+
+	if minUsedLimitPerc > 0 {
+		for scope, s := range all {
+			if s.usedLimitPerc() < minUsedLimitPerc {
+				delete(all, scope)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(all); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleDebugRcmgrLimitsScope serves PUT /debug/rcmgr/limits/{scope},
+// atomically swapping the limit for a single scope (e.g. "system",
+// "transient", "service:bitswap", "protocol:/ipfs/bitswap/1.2.0",
+// "peer:Qm...") without restarting the daemon. The host to update is
+// selected with the ?host=bitswap|dht query parameter, defaulting to
+// "bitswap".
+func handleDebugRcmgrLimitsScope(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "only PUT is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	scope := strings.TrimPrefix(r.URL.Path, "/debug/rcmgr/limits/")
+	if scope == "" {
+		http.Error(w, "missing scope", http.StatusBadRequest)
+		return
+	}
+
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		host = "bitswap"
+	}
+	limiter := currentRcmgrLimiters.bitswap
+	if host == "dht" {
+		limiter = currentRcmgrLimiters.dht
+	}
+	if limiter == nil {
+		http.Error(w, fmt.Sprintf("no resource manager for host %q", host), http.StatusNotFound)
+		return
+	}
+
+	var newLimit rcmgr.ResourceLimits
+	if err := json.NewDecoder(r.Body).Decode(&newLimit); err != nil {
+		http.Error(w, fmt.Sprintf("decoding limit: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	current := limiter.snapshot().ToPartialLimitConfig()
+	if err := setScopeLimit(&current, scope, newLimit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limiter.store(current.Build(limiter.snapshot()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setScopeLimit mutates cfg in place to set the limit for the named scope.
+func setScopeLimit(cfg *rcmgr.PartialLimitConfig, scope string, limit rcmgr.ResourceLimits) error {
+	switch {
+	case scope == "system":
+		cfg.System = limit
+	case scope == "transient":
+		cfg.Transient = limit
+	case strings.HasPrefix(scope, "service:"):
+		name := strings.TrimPrefix(scope, "service:")
+		if cfg.Service == nil {
+			cfg.Service = map[string]rcmgr.ResourceLimits{}
+		}
+		cfg.Service[name] = limit
+	case strings.HasPrefix(scope, "protocol:"):
+		name := protocol.ID(strings.TrimPrefix(scope, "protocol:"))
+		if cfg.Protocol == nil {
+			cfg.Protocol = map[protocol.ID]rcmgr.ResourceLimits{}
+		}
+		cfg.Protocol[name] = limit
+	case strings.HasPrefix(scope, "peer:"):
+		id, err := peer.Decode(strings.TrimPrefix(scope, "peer:"))
+		if err != nil {
+			return fmt.Errorf("invalid peer id in scope %q: %w", scope, err)
+		}
+		if cfg.Peer == nil {
+			cfg.Peer = map[peer.ID]rcmgr.ResourceLimits{}
+		}
+		cfg.Peer[id] = limit
+	default:
+		return fmt.Errorf("unrecognized scope %q", scope)
+	}
+	return nil
+}
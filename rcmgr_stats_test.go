@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+func TestSetScopeLimit(t *testing.T) {
+	limit := rcmgr.ResourceLimits{FD: rcmgr.LimitVal(7)}
+
+	t.Run("system", func(t *testing.T) {
+		var cfg rcmgr.PartialLimitConfig
+		if err := setScopeLimit(&cfg, "system", limit); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if cfg.System.FD != rcmgr.LimitVal(7) {
+			t.Errorf("expected System.FD to be set, got %v", cfg.System.FD)
+		}
+	})
+
+	t.Run("service", func(t *testing.T) {
+		var cfg rcmgr.PartialLimitConfig
+		if err := setScopeLimit(&cfg, "service:bitswap", limit); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if cfg.Service["bitswap"].FD != rcmgr.LimitVal(7) {
+			t.Errorf("expected Service[bitswap].FD to be set, got %v", cfg.Service["bitswap"].FD)
+		}
+	})
+
+	t.Run("protocol", func(t *testing.T) {
+		var cfg rcmgr.PartialLimitConfig
+		if err := setScopeLimit(&cfg, "protocol:/ipfs/bitswap/1.2.0", limit); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if cfg.Protocol["/ipfs/bitswap/1.2.0"].FD != rcmgr.LimitVal(7) {
+			t.Errorf("expected Protocol entry to be set, got %v", cfg.Protocol)
+		}
+	})
+
+	t.Run("invalid peer id", func(t *testing.T) {
+		var cfg rcmgr.PartialLimitConfig
+		if err := setScopeLimit(&cfg, "peer:not-a-peer-id", limit); err == nil {
+			t.Error("expected an error for an invalid peer id, got nil")
+		}
+	})
+
+	t.Run("unrecognized scope", func(t *testing.T) {
+		var cfg rcmgr.PartialLimitConfig
+		if err := setScopeLimit(&cfg, "bogus", limit); err == nil {
+			t.Error("expected an error for an unrecognized scope, got nil")
+		}
+	})
+}
+
+func TestUsedLimitPerc(t *testing.T) {
+	concrete := rcmgr.PartialLimitConfig{
+		System: rcmgr.ResourceLimits{Memory: rcmgr.LimitVal64(100)},
+	}.Build(rcmgr.ConcreteLimitConfig{})
+
+	s := rcmgrScopeStat{
+		Stat:  network.ScopeStat{Memory: 50},
+		Limit: concrete.GetSystemLimits(),
+	}
+
+	if got := s.usedLimitPerc(); got != 50 {
+		t.Errorf("usedLimitPerc() = %v, want 50 (50/100 memory used)", got)
+	}
+}
+
+// TestCollectRcmgrStats exercises collectRcmgrStats against a real
+// network.ResourceManager (not a fake), so a mismatch between
+// resourceManagerState and rcmgr's actual introspection API fails this test
+// instead of only surfacing as a 500 at runtime.
+func TestCollectRcmgrStats(t *testing.T) {
+	limits := rcmgr.PartialLimitConfig{
+		System: rcmgr.ResourceLimits{
+			Memory: rcmgr.LimitVal64(1 << 20),
+			FD:     rcmgr.LimitVal(64),
+			Conns:  rcmgr.LimitVal(64),
+		},
+	}.Build(rcmgr.InfiniteLimits)
+
+	rm, err := rcmgr.NewResourceManager(rcmgr.NewFixedLimiter(limits))
+	if err != nil {
+		t.Fatalf("NewResourceManager: %s", err)
+	}
+	defer rm.Close()
+
+	stats, err := collectRcmgrStats("bitswap", rm)
+	if err != nil {
+		t.Fatalf("collectRcmgrStats: %s", err)
+	}
+
+	if _, ok := stats["bitswap:system"]; !ok {
+		t.Errorf("expected a %q entry, got keys %v", "bitswap:system", stats)
+	}
+	if _, ok := stats["bitswap:transient"]; !ok {
+		t.Errorf("expected a %q entry, got keys %v", "bitswap:transient", stats)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+)
+
+func TestApplyUserLimits(t *testing.T) {
+	base := rcmgr.PartialLimitConfig{
+		System: rcmgr.ResourceLimits{
+			Memory: rcmgr.LimitVal64(1024),
+			FD:     rcmgr.LimitVal(100),
+		},
+	}.Build(rcmgr.ConcreteLimitConfig{})
+
+	userLimits := rcmgr.PartialLimitConfig{
+		System: rcmgr.ResourceLimits{
+			FD: rcmgr.LimitVal(10),
+		},
+	}
+
+	got := applyUserLimits(userLimits, base)
+
+	if got.ToPartialLimitConfig().System.FD != rcmgr.LimitVal(10) {
+		t.Errorf("expected user-set FD override to win, got %v", got.ToPartialLimitConfig().System.FD)
+	}
+	if got.ToPartialLimitConfig().System.Memory != rcmgr.LimitVal64(1024) {
+		t.Errorf("expected computed Memory to fall through unchanged, got %v", got.ToPartialLimitConfig().System.Memory)
+	}
+}
+
+// TestReloadableLimiterReloadsFromBase guards against a reload that merges
+// new overrides onto the live (already-merged) snapshot instead of the
+// original computed base: doing so would make removing a key from the
+// limits file a no-op, since the stale override from the previous reload
+// would still be baked into what gets merged onto.
+func TestReloadableLimiterReloadsFromBase(t *testing.T) {
+	base := rcmgr.PartialLimitConfig{
+		System: rcmgr.ResourceLimits{
+			FD: rcmgr.LimitVal(100),
+		},
+	}.Build(rcmgr.ConcreteLimitConfig{})
+
+	limiter := newReloadableLimiter(base)
+
+	firstOverride := rcmgr.PartialLimitConfig{System: rcmgr.ResourceLimits{FD: rcmgr.LimitVal(10)}}
+	limiter.store(applyUserLimits(firstOverride, limiter.base))
+	if got := limiter.snapshot().ToPartialLimitConfig().System.FD; got != rcmgr.LimitVal(10) {
+		t.Fatalf("expected first override to apply, got %v", got)
+	}
+
+	// Simulate SIGHUP with the override removed from the file entirely.
+	secondOverride := rcmgr.PartialLimitConfig{}
+	limiter.store(applyUserLimits(secondOverride, limiter.base))
+
+	if got := limiter.snapshot().ToPartialLimitConfig().System.FD; got != rcmgr.LimitVal(100) {
+		t.Errorf("expected reload to revert to base FD 100 once the override was removed from the file, got %v", got)
+	}
+}